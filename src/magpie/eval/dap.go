@@ -0,0 +1,283 @@
+package eval
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+)
+
+// DebugFrontend drives the user-facing side of a Debugger. Run blocks
+// until the session should resume execution (mirroring the old
+// ProcessCommand loop), reading commands from whatever medium the
+// implementation wraps and mutating d.Session in response.
+type DebugFrontend interface {
+	Run(d *Debugger)
+}
+
+// TerminalFrontend is the original stdin/stdout REPL: it prints the
+// current source line, prompts with "(magpie) " and accepts the classic
+// single-letter commands (c, n, b, d, p, l, ...).
+type TerminalFrontend struct{}
+
+func (t *TerminalFrontend) Run(d *Debugger) {
+	d.terminalLoop()
+}
+
+// Event is a DAP-style notification the debugger pushes out over
+// Debugger.Events. Type is one of the Event* constants below; Body carries
+// whatever payload makes sense for that type (a line number for
+// EventStopped/EventBreakpoint, a string for EventOutput, nothing for
+// EventTerminated).
+type Event struct {
+	Type string
+	Body interface{}
+}
+
+const (
+	EventStopped    = "stopped"
+	EventBreakpoint = "breakpoint"
+	EventOutput     = "output"
+	EventTerminated = "terminated"
+)
+
+// dapMessage is the JSON-RPC-ish envelope DAP uses over stdio/TCP: every
+// request has a seq and command, every response/event carries the
+// sender's own next seq (send numbers them; the request's seq is not
+// echoed back) and a body.
+type dapMessage struct {
+	Seq     int             `json:"seq"`
+	Type    string          `json:"type"` // "request", "response", or "event"
+	Command string          `json:"command,omitempty"`
+	Event   string          `json:"event,omitempty"`
+	Success bool            `json:"success,omitempty"`
+	Body    json.RawMessage `json:"body,omitempty"`
+}
+
+// DAPFrontend implements enough of the Debug Adapter Protocol for VS Code
+// (or any other DAP-aware editor) to attach to a running Magpie program
+// over stdio or a TCP connection: initialize, launch, setBreakpoints,
+// setFunctionBreakpoints, stackTrace, scopes, variables, evaluate,
+// continue, next, stepIn, stepOut and pause, plus the stopped/breakpoint/
+// output/terminated events.
+type DAPFrontend struct {
+	rw  io.ReadWriter
+	seq int
+
+	// sendMu guards seq and writes to rw: pumpEvents and the request-
+	// handling goroutine in Run both call send, and without a lock their
+	// writes could interleave on the wire and seq could race.
+	sendMu sync.Mutex
+
+	// pumpOnce ensures pumpEvents starts exactly once per DAPFrontend --
+	// ProcessCommand calls Run again every time the debuggee stops, but
+	// it's the same DAPFrontend instance each time, so it must not spawn
+	// a fresh event pump (and leak the old one) on every stop.
+	pumpOnce sync.Once
+
+	// closeOnce guards closing d.Events on disconnect/terminate so
+	// pumpEvents's range loop actually exits instead of leaking forever.
+	closeOnce sync.Once
+}
+
+// NewDAPFrontend wraps rw (a stdio pipe, or a net.Conn accepted from a TCP
+// listener) as a DAP server for d.
+func NewDAPFrontend(rw io.ReadWriter) *DAPFrontend {
+	return &DAPFrontend{rw: rw}
+}
+
+// ListenDAP starts a TCP listener at addr and installs a DAPFrontend on d
+// as soon as an editor connects, the usual "launch the debuggee, then have
+// the editor connect" DAP workflow. d.Frontend.Run is then driven by
+// ProcessCommand every time the debuggee actually stops -- pumpOnce and
+// closeOnce on DAPFrontend depend on it being the same instance across
+// those repeated calls, so the connection is closed from handle's
+// disconnect/terminate branch, not here.
+func ListenDAP(addr string, d *Debugger) (net.Listener, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		d.Frontend = NewDAPFrontend(conn)
+	}()
+	return ln, nil
+}
+
+func (f *DAPFrontend) Run(d *Debugger) {
+	f.pumpOnce.Do(func() { go f.pumpEvents(d) })
+
+	scanner := bufio.NewScanner(f.rw)
+	for scanner.Scan() {
+		var req dapMessage
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			continue
+		}
+
+		resume := f.handle(d, req)
+		if resume {
+			return
+		}
+	}
+}
+
+// pumpEvents forwards d.Events onto the DAP connection as "event" messages
+// until d.Events is closed or the connection breaks.
+func (f *DAPFrontend) pumpEvents(d *Debugger) {
+	for ev := range d.Events {
+		body, _ := json.Marshal(map[string]interface{}{"reason": ev.Body})
+		if err := f.send(dapMessage{Type: "event", Event: ev.Type, Body: body}); err != nil {
+			return
+		}
+	}
+}
+
+// handle dispatches a single DAP request, returning true once the session
+// should resume execution (the "continue"/"next"/"stepIn"/"stepOut"
+// requests, which map onto the same stepping semantics as the terminal
+// REPL's "c"/"n" commands), or once a "disconnect"/"terminate" request has
+// closed the session.
+func (f *DAPFrontend) handle(d *Debugger, req dapMessage) (resume bool) {
+	switch req.Command {
+	case "initialize":
+		f.respond(req, map[string]interface{}{"supportsFunctionBreakpoints": true})
+	case "launch", "attach":
+		f.respond(req, nil)
+	case "setBreakpoints":
+		var args struct {
+			Breakpoints []struct{ Line int `json:"line"` } `json:"breakpoints"`
+		}
+		json.Unmarshal(req.Body, &args)
+		for _, bp := range args.Breakpoints {
+			d.AddBP(bp.Line)
+		}
+		f.respond(req, nil)
+	case "setFunctionBreakpoints":
+		var args struct {
+			Breakpoints []struct{ Name string `json:"name"` } `json:"breakpoints"`
+		}
+		json.Unmarshal(req.Body, &args)
+		for _, bp := range args.Breakpoints {
+			for _, fi := range d.Session.FuncLines {
+				if fi.name == bp.Name {
+					fi.enabled = true
+				}
+			}
+		}
+		f.respond(req, nil)
+	case "stackTrace":
+		f.respond(req, map[string]interface{}{"stackFrames": f.stackFrames(d)})
+	case "scopes":
+		var args struct{ FrameId int `json:"frameId"` }
+		json.Unmarshal(req.Body, &args)
+		f.respond(req, map[string]interface{}{
+			// variablesReference is offset by one so 0 can keep meaning
+			// "no variables" per the DAP spec; a later "variables" request
+			// subtracts it back off to find this frame's scope again.
+			"scopes": []map[string]interface{}{{"name": "Locals", "variablesReference": args.FrameId + 1}},
+		})
+	case "variables":
+		var args struct{ VariablesReference int `json:"variablesReference"` }
+		json.Unmarshal(req.Body, &args)
+		f.respond(req, map[string]interface{}{"variables": f.variables(f.frameScope(d, args.VariablesReference-1))})
+	case "evaluate":
+		var args struct{ Expression string `json:"expression"` }
+		json.Unmarshal(req.Body, &args)
+		result := d.evalString(args.Expression)
+		f.respond(req, map[string]interface{}{"result": result.Inspect()})
+	case "continue":
+		d.Session.Stepping = false
+		f.respond(req, nil)
+		resume = true
+	case "next", "stepIn", "stepOut":
+		d.Session.Stepping = true
+		f.respond(req, nil)
+		resume = true
+	case "pause":
+		f.respond(req, nil)
+	case "disconnect", "terminate":
+		f.send(dapMessage{Type: "event", Event: EventTerminated})
+		f.closeOnce.Do(func() {
+			close(d.Events)
+			if closer, ok := f.rw.(io.Closer); ok {
+				closer.Close()
+			}
+		})
+		resume = true
+	default:
+		f.respond(req, nil)
+	}
+	return resume
+}
+
+// stackFrames walks Scope.parentScope from the paused node's scope
+// outward, producing one DAP stack frame per enclosing scope. Only frame
+// 0 -- the scope execution is actually paused in -- has a real source
+// line; Magpie's Scope doesn't record the line a call was made from, so
+// outer frames report -1 rather than repeating frame 0's line as if it
+// were theirs too.
+func (f *DAPFrontend) stackFrames(d *Debugger) []map[string]interface{} {
+	var frames []map[string]interface{}
+	i := 0
+	for s := d.Session.Scope; s != nil; s = s.parentScope {
+		line := -1
+		if i == 0 {
+			line = d.Session.Node.Pos().Line
+		}
+		frames = append(frames, map[string]interface{}{
+			"id":   i,
+			"name": fmt.Sprintf("frame %d", i),
+			"line": line,
+		})
+		i++
+	}
+	return frames
+}
+
+// frameScope returns the i'th scope outward from the paused scope
+// (0 = innermost, matching the ids stackFrames hands out), so "variables"
+// can report the selected frame's own locals instead of always the
+// innermost scope's.
+func (f *DAPFrontend) frameScope(d *Debugger, i int) *Scope {
+	s := d.Session.Scope
+	for ; i > 0 && s != nil; i-- {
+		s = s.parentScope
+	}
+	return s
+}
+
+func (f *DAPFrontend) variables(scope *Scope) []map[string]interface{} {
+	var vars []map[string]interface{}
+	if scope == nil {
+		return vars
+	}
+	for name, obj := range scope.store {
+		vars = append(vars, map[string]interface{}{"name": name, "value": obj.Inspect()})
+	}
+	return vars
+}
+
+func (f *DAPFrontend) respond(req dapMessage, body interface{}) {
+	b, _ := json.Marshal(body)
+	f.send(dapMessage{Type: "response", Command: req.Command, Success: true, Body: b})
+}
+
+func (f *DAPFrontend) send(msg dapMessage) error {
+	f.sendMu.Lock()
+	defer f.sendMu.Unlock()
+
+	f.seq++
+	msg.Seq = f.seq
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = f.rw.Write(append(b, '\n'))
+	return err
+}
@@ -3,6 +3,7 @@ package eval
 import (
 	"bufio"
 	"fmt"
+	"io/ioutil"
 	"magpie/ast"
 	"magpie/lexer"
 	"magpie/parser"
@@ -22,20 +23,46 @@ type FuncInfo struct {
 	end   int
 }
 
-type Debugger struct {
-	SrcLines []string
-
+// DebugSession holds the state a running debug target needs regardless of
+// which frontend is driving it: breakpoints, the node/scope execution is
+// currently paused at, and whether single-stepping is active. It's the
+// shared state a TerminalFrontend and a DAPFrontend both read and mutate.
+type DebugSession struct {
 	//for function breakpoint
-	Functions map[string]*ast.FunctionLiteral
 	FuncLines []*FuncInfo
 
 	//for line number breakpoint
 	Breakpoints map[int]bool
 
-	Node ast.Node
+	Node  ast.Node
 	Scope *Scope
 
 	Stepping bool
+}
+
+func newDebugSession() *DebugSession {
+	return &DebugSession{
+		Breakpoints: make(map[int]bool),
+		Stepping:    true,
+	}
+}
+
+type Debugger struct {
+	SrcLines []string
+
+	//for function breakpoint
+	Functions map[string]*ast.FunctionLiteral
+
+	Session *DebugSession
+
+	// Frontend drives the user-facing side of the debugger (a terminal
+	// REPL, a DAP server, ...). It defaults to a TerminalFrontend.
+	Frontend DebugFrontend
+
+	// Events carries DAP-style notifications (stopped/breakpoint/output/
+	// terminated) out of the debugger so a frontend -- or anything else
+	// watching the session -- can react without polling.
+	Events chan Event
 
 	prevCommand string
 	showPrompt bool
@@ -44,54 +71,78 @@ type Debugger struct {
 
 func NewDebugger(lines []string) *Debugger {
 	d := &Debugger{SrcLines: lines}
-	d.Breakpoints = make(map[int]bool)
+	d.Session = newDebugSession()
+	d.Frontend = &TerminalFrontend{}
+	d.Events = make(chan Event, 16)
 	d.showPrompt = true
-	d.Stepping = true
 	d.prevCommand = ""
 
 	return d
 }
 
+// NewDebuggerFromFiles parses filenames concurrently via parser.BatchParse
+// so a large multi-file Magpie project loads in parallel at debugger
+// startup instead of one file at a time. It returns a Debugger displaying
+// the first file's source, alongside the parsed programs and any per-file
+// parse errors in filename order -- the Debugger itself has no functions
+// or paused node/scope wired in yet, since that depends on how the caller
+// evaluates the programs it gets back; call SetFunctions and
+// SetNodeAndScope once that's known, before handing the Debugger to a
+// Frontend.
+func NewDebuggerFromFiles(filenames []string, concurrency int) (*Debugger, []*ast.Program, []error) {
+	programs, errs := parser.BatchParse(filenames, concurrency)
+
+	var lines []string
+	if len(filenames) > 0 {
+		if src, err := ioutil.ReadFile(filenames[0]); err == nil {
+			lines = strings.Split(string(src), "\n")
+		}
+	}
+
+	return NewDebugger(lines), programs, errs
+}
+
 // Add a breakpoint at source line
 func (d *Debugger) AddBP(line int) {
-	d.Breakpoints[line] = true
+	d.Session.Breakpoints[line] = true
+	d.emit(Event{Type: EventBreakpoint, Body: line})
 }
 
 // Delete a breakpoint at source line
 func (d *Debugger) DelBP(line int) {
-	if _, ok := d.Breakpoints[line]; ok {
-		delete(d.Breakpoints, line)
+	if _, ok := d.Session.Breakpoints[line]; ok {
+		delete(d.Session.Breakpoints, line)
 	}
 }
 
 // Check if a source line is at a breakpoint
 func (d *Debugger) IsBP(line int) bool {
-	_, ok := d.Breakpoints[line];
+	_, ok := d.Session.Breakpoints[line];
 	return ok
 }
 
 func (d * Debugger) SetNodeAndScope(node ast.Node, scope *Scope) {
-	d.Node = node
-	d.Scope = scope
+	d.Session.Node = node
+	d.Session.Scope = scope
 }
 
 func (d * Debugger) SetFunctions(functions map[string]*ast.FunctionLiteral) {
 	d.Functions = functions
 	for fname, node := range d.Functions {
 		fi := &FuncInfo{name:fname, begin: node.StmtPos().Line, end: node.End().Line, enabled: false}
-		d.FuncLines = append(d.FuncLines, fi)
+		d.Session.FuncLines = append(d.Session.FuncLines, fi)
 	}
 
 }
 
 func (d * Debugger) IsFunctionBP(line int) bool {
-	if len(d.FuncLines) == 0 {
+	if len(d.Session.FuncLines) == 0 {
 		return false
 	}
 
 	found := false
 	var fi *FuncInfo
-	for _, f := range d.FuncLines {
+	for _, f := range d.Session.FuncLines {
 		if f.enabled {
 			found = true
 			fi = f
@@ -108,7 +159,7 @@ func (d * Debugger) IsFunctionBP(line int) bool {
 	return false
 }
 
-func (d * Debugger) ShowBanner() {
+func (d *Debugger) ShowBanner() {
 	fmt.Println("                                    _     ")
 	fmt.Println("   ____ ___   ____ _ ____ _ ____   (_)___ ")
 	fmt.Println("  / __ `__ \\ / __ `// __ `// __ \\ / // _ \\")
@@ -118,13 +169,130 @@ func (d * Debugger) ShowBanner() {
 	fmt.Println("");
 }
 
+// emit sends an event on d.Events without blocking the debugger when
+// nothing is listening -- events are best-effort notifications, not a
+// synchronization mechanism.
+func (d *Debugger) emit(ev Event) {
+	// A DAPFrontend closes d.Events once the session disconnects; recover
+	// rather than let a late emit (e.g. the debuggee hitting one more
+	// breakpoint while tearing down) panic the debugger.
+	defer func() { recover() }()
+
+	select {
+	case d.Events <- ev:
+	default:
+	}
+}
+
+// ProcessCommand pauses execution at the current node and hands control to
+// d.Frontend until it reports the target should resume. It used to contain
+// a hardwired stdin/stdout REPL; that loop now lives in TerminalFrontend,
+// the default Frontend, so behavior is unchanged unless a different
+// frontend (e.g. DAPFrontend) is installed.
 func (d *Debugger) ProcessCommand() {
+	if d.Frontend == nil {
+		d.Frontend = &TerminalFrontend{}
+	}
+
+	d.emit(Event{Type: EventStopped, Body: d.Session.Node.Pos().Line})
+	d.Frontend.Run(d)
+}
+
+//Check if node can be stopped, some nodes cannot be stopped,
+//e.g. 'InfixExpression', 'IntegerLiteral'
+func (d *Debugger) CanStop() bool {
+	//check if function breakpoint is enabled
+	for _, fi := range d.Session.FuncLines {
+		if !fi.enabled {
+			if d.Session.Node.Pos().Line >= fi.begin && d.Session.Node.Pos().Line <= fi.end {
+				return false
+			}
+		}
+	}
+
+	flag := false
+	switch d.Session.Node.(type) {
+	case *ast.LetStatement:
+		flag = true
+	case *ast.ConstStatement:
+		flag = true
+	case *ast.ReturnStatement:
+		flag = true
+	case *ast.DeferStmt:
+		flag = true
+	case *ast.EnumStatement:
+		flag = true
+	case *ast.IfExpression:
+		flag = true
+	case *ast.UnlessExpression:
+		flag = true
+	case *ast.CaseExpr:
+		flag = true
+	case *ast.DoLoop:
+		flag = true
+	case *ast.WhileLoop:
+		flag = true
+	case *ast.ForLoop:
+		flag = true
+	case *ast.ForEverLoop:
+		flag = true
+	case *ast.ForEachArrayLoop:
+		flag = true
+	case *ast.ForEachDotRange:
+		flag = true
+	case *ast.ForEachMapLoop:
+		flag = true
+	case *ast.BreakExpression:
+		flag = true
+	case *ast.ContinueExpression:
+		flag = true
+	case *ast.AssignExpression:
+		flag = true
+	case *ast.CallExpression:
+		flag = true
+	case *ast.TryStmt:
+		flag = true
+	case *ast.SpawnStmt:
+		flag = true
+	case *ast.UsingStmt:
+		flag = true
+	case *ast.QueryExpr:
+		flag = true
+	default:
+		flag = false
+	}
+
+	return flag
+}
+
+// evalString lexes, parses and evaluates expr against the session's current
+// scope, restoring SrcLines/Node/showPrompt around the call the same way
+// the old inline "p "/"eval " command handler did. Both frontends use it
+// for watch expressions.
+func (d *Debugger) evalString(expr string) Object {
+	lex := lexer.New("", expr)
+	wd, _ := os.Getwd()
+	p := parser.New(lex, wd)
+	oldLines := d.SrcLines
+	oldNode := d.Session.Node
+	d.showPrompt = false
+	program := p.ParseProgram()
+	aval := Eval(program, d.Session.Scope)
+	d.SrcLines = oldLines
+	d.Session.Node = oldNode
+	d.showPrompt = true
+	return aval
+}
+
+// terminalLoop is the original stdin/stdout command loop, preserved as a
+// method so TerminalFrontend.Run can call straight into it.
+func (d *Debugger) terminalLoop() {
 	for {
 		if !d.showPrompt {
 			break
 		}
 
-		p := d.Node.Pos()
+		p := d.Session.Node.Pos()
 
 		fmt.Printf("\n%d\t\t%s", p.Line, d.SrcLines[p.Line])
 		fmt.Print("\n(magpie) ")
@@ -142,11 +310,11 @@ func (d *Debugger) ProcessCommand() {
 
 		d.prevCommand = command
 
-		d.Stepping = false
+		d.Session.Stepping = false
 		if strings.Compare("c", command) == 0 || strings.Compare("continue", command) == 0 {
 			break
 		} else if strings.Compare("n", command) == 0 || strings.Compare("next", command) == 0 {
-			d.Stepping = true
+			d.Session.Stepping = true
 			break
 		} else if strings.HasPrefix(command, "b ")|| strings.HasPrefix(command, "bp ") {
 			arr := strings.Split(command, " ")
@@ -165,7 +333,7 @@ func (d *Debugger) ProcessCommand() {
 					if _, ok := d.Functions[funcName]; !ok {
 						fmt.Println("Function name not found.")
 					} else {
-						for _, fi := range d.FuncLines {
+						for _, fi := range d.Session.FuncLines {
 							if fi.name == funcName {
 								fi.enabled = true
 								break
@@ -192,7 +360,7 @@ func (d *Debugger) ProcessCommand() {
 					if _, ok := d.Functions[funcName]; !ok {
 						fmt.Println("Function name not found.")
 					} else {
-						for _, fi := range d.FuncLines {
+						for _, fi := range d.Session.FuncLines {
 							if fi.name == funcName {
 								fi.enabled = false
 								break
@@ -205,18 +373,8 @@ func (d *Debugger) ProcessCommand() {
 		} else if strings.HasPrefix(command, "p ") || strings.HasPrefix(command, "print ") ||
 			strings.HasPrefix(command, "e ") || strings.HasPrefix(command, "eval ") {
 			exp := strings.Split(command, " ")[1:]
-			lex := lexer.New("", strings.Join(exp, ""))
-			wd, _ := os.Getwd()
-			p := parser.New(lex, wd)
-			oldLines := d.SrcLines
-			oldNode := d.Node
-			d.showPrompt = false
-			program := p.ParseProgram()
-			aval := Eval(program, d.Scope)
+			aval := d.evalString(strings.Join(exp, ""))
 			fmt.Printf("%s\n", aval.Inspect())
-			d.SrcLines = oldLines
-			d.Node = oldNode
-			d.showPrompt = true
 		} else if strings.Compare("exit", command) == 0 || strings.Compare("quit", command) == 0 ||
 				  strings.Compare("bye", command) == 0 || strings.Compare("q", command) == 0 {
 			os.Exit(0)
@@ -244,70 +402,3 @@ func (d *Debugger) ProcessCommand() {
 		}
 	} //end for
 }
-
-//Check if node can be stopped, some nodes cannot be stopped, 
-//e.g. 'InfixExpression', 'IntegerLiteral'
-func (d *Debugger) CanStop() bool {
-	//check if function breakpoint is enabled
-	for _, fi := range d.FuncLines {
-		if !fi.enabled {
-			if d.Node.Pos().Line >= fi.begin && d.Node.Pos().Line <= fi.end {
-				return false
-			}
-		}
-	}
-
-	flag := false
-	switch d.Node.(type) {
-	case *ast.LetStatement:
-		flag = true
-	case *ast.ConstStatement:
-		flag = true
-	case *ast.ReturnStatement:
-		flag = true
-	case *ast.DeferStmt:
-		flag = true
-	case *ast.EnumStatement:
-		flag = true
-	case *ast.IfExpression:
-		flag = true
-	case *ast.UnlessExpression:
-		flag = true
-	case *ast.CaseExpr:
-		flag = true
-	case *ast.DoLoop:
-		flag = true
-	case *ast.WhileLoop:
-		flag = true
-	case *ast.ForLoop:
-		flag = true
-	case *ast.ForEverLoop:
-		flag = true
-	case *ast.ForEachArrayLoop:
-		flag = true
-	case *ast.ForEachDotRange:
-		flag = true
-	case *ast.ForEachMapLoop:
-		flag = true
-	case *ast.BreakExpression:
-		flag = true
-	case *ast.ContinueExpression:
-		flag = true
-	case *ast.AssignExpression:
-		flag = true
-	case *ast.CallExpression:
-		flag = true
-	case *ast.TryStmt:
-		flag = true
-	case *ast.SpawnStmt:
-		flag = true
-	case *ast.UsingStmt:
-		flag = true
-	case *ast.QueryExpr:
-		flag = true
-	default:
-		flag = false
-	}
-
-	return flag
-}
\ No newline at end of file
@@ -0,0 +1,90 @@
+package parser
+
+import (
+	"io/ioutil"
+	"magpie/ast"
+	"magpie/lexer"
+	"runtime"
+)
+
+// extraParsers is added on top of runtime.GOMAXPROCS(0) when sizing
+// BatchParse's worker pool, the same shape as the Go compiler's
+// noder.parseFiles: a handful of extra goroutines keep the pool busy while
+// some workers are blocked reading their file off disk.
+const extraParsers = 2
+
+// BatchParse parses each of filenames concurrently across a bounded worker
+// pool (size = runtime.GOMAXPROCS(0)+concurrency, or +extraParsers if
+// concurrency <= 0) and returns the resulting programs and any errors in
+// the same order as filenames, regardless of which worker finished first.
+func BatchParse(filenames []string, concurrency int) ([]*ast.Program, []error) {
+	if concurrency <= 0 {
+		concurrency = extraParsers
+	}
+	workers := runtime.GOMAXPROCS(0) + concurrency
+	if workers > len(filenames) {
+		workers = len(filenames)
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	programs := make([]*ast.Program, len(filenames))
+	errs := make([]error, len(filenames))
+
+	jobs := make(chan int)
+	done := make(chan struct{})
+
+	for w := 0; w < workers; w++ {
+		go func() {
+			for i := range jobs {
+				programs[i], errs[i] = parseFile(filenames[i])
+			}
+			done <- struct{}{}
+		}()
+	}
+
+	go func() {
+		for i := range filenames {
+			jobs <- i
+		}
+		close(jobs)
+	}()
+
+	for w := 0; w < workers; w++ {
+		<-done
+	}
+
+	return programs, errs
+}
+
+// parseFile reads and parses a single file, the per-file unit of work
+// BatchParse's worker pool fans out.
+func parseFile(filename string) (*ast.Program, error) {
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	lex := lexer.New(filename, string(src))
+	p := New(lex, filename)
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		return program, &ParseError{Filename: filename, Messages: errs}
+	}
+	return program, nil
+}
+
+// ParseError reports the parser's accumulated error messages for a single
+// file parsed by BatchParse.
+type ParseError struct {
+	Filename string
+	Messages []string
+}
+
+func (e *ParseError) Error() string {
+	if len(e.Messages) == 0 {
+		return e.Filename + ": parse error"
+	}
+	return e.Filename + ": " + e.Messages[0]
+}
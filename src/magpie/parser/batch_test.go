@@ -0,0 +1,49 @@
+package parser
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// writeBenchFiles materializes n copies of a small Magpie source snippet
+// under a temp dir, returning their paths for BatchParse to chew through.
+func writeBenchFiles(tb testing.TB, n int) []string {
+	dir, err := ioutil.TempDir("", "magpie-batchparse")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { os.RemoveAll(dir) })
+
+	src := strings.Repeat("let x = 1 + 2 * 3\n", 50)
+
+	files := make([]string, n)
+	for i := 0; i < n; i++ {
+		path := filepath.Join(dir, strconv.Itoa(i)+".mp")
+		if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+			tb.Fatal(err)
+		}
+		files[i] = path
+	}
+	return files
+}
+
+// BenchmarkBatchParse reports parsed lines/sec, matching the reporting
+// style comparable fast-parser benchmarks use (lines/sec rather than raw
+// ns/op, since file size dominates wall time far more than call overhead).
+func BenchmarkBatchParse(b *testing.B) {
+	files := writeBenchFiles(b, 64)
+	linesPerFile := 50
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		BatchParse(files, 0)
+	}
+	b.StopTimer()
+
+	totalLines := float64(len(files) * linesPerFile * b.N)
+	b.ReportMetric(totalLines/b.Elapsed().Seconds(), "lines/sec")
+}
@@ -4,16 +4,22 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"magpie/eval"
 	"magpie/lexer"
 	"magpie/parser"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 
 	"github.com/peterh/liner"
 )
 
+// batchParseConcurrency is the "+N" added to runtime.GOMAXPROCS(0) when
+// sizing parser.BatchParse's worker pool for loadIncludes.
+const batchParseConcurrency = 2
+
 var magpieKeywords = []string{
 	"fn", "let", "true", "false", "if", "else", "elsif", "elseif",
 	"elif", "return", "include", "and", "or", "struct", "do", "while",
@@ -51,7 +57,11 @@ var colors = map[liner.Category]string{
 const PROMPT = "magpie>> "
 const CONT_PROMPT = "... " // continue prompt
 
-func Start(out io.Writer, color bool) {
+// Start runs the interactive REPL loop. Any paths in includes are parsed
+// concurrently via parser.BatchParse and evaluated into the initial scope
+// before the first prompt, so large multi-file Magpie projects load in
+// parallel instead of one include at a time on the main goroutine.
+func Start(out io.Writer, color bool, includes ...string) {
 	history := filepath.Join(os.TempDir(), ".magpie_history")
 	l := liner.NewLiner()
 	defer l.Close()
@@ -81,6 +91,11 @@ func Start(out io.Writer, color bool) {
 		os.Exit(1)
 	}
 
+	sess := &replSession{out: out, scope: scope, wd: wd}
+	if len(includes) > 0 {
+		loadIncludes(out, includes, scope)
+	}
+
 	// var tmplines []string
 	for {
 		if line, err := l.Prompt(PROMPT); err == nil {
@@ -95,13 +110,13 @@ func Start(out io.Writer, color bool) {
 			tmpline := strings.TrimSpace(line)
 			if len(tmpline) == 0 || tmpline[0] == '#' { //empty line or single comment line
 				continue
+			} else if strings.HasPrefix(tmpline, ":") {
+				sess.handleMetaCommand(tmpline, l)
+				continue
 			} else {
 				//check if the line is a valid expression or statement
-				lex := lexer.New("", tmpline)
-				p := parser.New(lex, wd)
-				program := p.ParseProgram()
-				if len(p.Errors()) == 0 { // no error
-					eval.Eval(program, scope)
+				if sess.evalSource(tmpline) {
+					sess.inputs = append(sess.inputs, tmpline)
 					l.AppendHistory(tmpline)
 					continue
 				} else {
@@ -112,11 +127,8 @@ func Start(out io.Writer, color bool) {
 							fmt.Fprintln(&buf, line)
 
 							text := string(buf.Bytes())
-							lex := lexer.New("", text)
-							p := parser.New(lex, wd)
-							program := p.ParseProgram()
-							if len(p.Errors()) == 0 { // no error
-								eval.Eval(program, scope)
+							if sess.evalSource(text) {
+								sess.inputs = append(sess.inputs, text)
 								l.AppendHistory(strings.Replace(text, "\n", "", -1))
 								break
 							} else {
@@ -131,6 +143,194 @@ func Start(out io.Writer, color bool) {
 	}
 }
 
+// replSession holds the mutable state that persists across prompts: the
+// evaluation scope, the working directory used to resolve includes, the
+// last file loaded via :load/:reload, and the inputs :save can write back
+// out.
+type replSession struct {
+	out      io.Writer
+	scope    *eval.Scope
+	wd       string
+	lastFile string
+	inputs   []string
+}
+
+// evalSource lexes, parses and evaluates src against sess.scope, returning
+// false (without evaluating) if it doesn't parse -- the caller falls back
+// to the multi-line continuation prompt in that case, same as before this
+// was factored out.
+func (sess *replSession) evalSource(src string) bool {
+	lex := lexer.New("", src)
+	p := parser.New(lex, sess.wd)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return false
+	}
+	eval.Eval(program, sess.scope)
+	return true
+}
+
+// handleMetaCommand parses and runs a single colon-prefixed command.
+// Unrecognized commands are reported and otherwise ignored.
+func (sess *replSession) handleMetaCommand(line string, l *liner.State) {
+	l.AppendHistory(line)
+
+	fields := strings.Fields(line)
+	cmd := fields[0]
+	args := fields[1:]
+
+	switch cmd {
+	case ":load":
+		if len(args) < 1 {
+			fmt.Fprintln(sess.out, "usage: :load <file>")
+			return
+		}
+		sess.loadFile(args[0])
+
+	case ":reload":
+		if sess.lastFile == "" {
+			fmt.Fprintln(sess.out, "no file loaded yet; use :load <file> first")
+			return
+		}
+		sess.loadFile(sess.lastFile)
+
+	case ":edit":
+		sess.editAndEval()
+
+	case ":type":
+		if len(args) < 1 {
+			fmt.Fprintln(sess.out, "usage: :type <expr>")
+			return
+		}
+		sess.printType(strings.Join(args, " "))
+
+	case ":reset":
+		sess.scope = eval.NewScope(nil)
+		fmt.Fprintln(sess.out, "scope reset")
+
+	case ":history":
+		for _, in := range sess.inputs {
+			fmt.Fprintln(sess.out, in)
+		}
+
+	case ":save":
+		if len(args) < 1 {
+			fmt.Fprintln(sess.out, "usage: :save <file>")
+			return
+		}
+		sess.save(args[0])
+
+	default:
+		fmt.Fprintf(sess.out, "Undefined command: '%s'.\n", cmd)
+	}
+}
+
+// loadFile parses and evaluates file into sess.scope and remembers it as
+// the target for a subsequent :reload.
+func (sess *replSession) loadFile(file string) {
+	src, err := ioutil.ReadFile(file)
+	if err != nil {
+		fmt.Fprintln(sess.out, err)
+		return
+	}
+
+	lex := lexer.New(file, string(src))
+	p := parser.New(lex, sess.wd)
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) != 0 {
+		printParserErrors(sess.out, errs)
+		return
+	}
+
+	eval.Eval(program, sess.scope)
+	sess.lastFile = file
+}
+
+// editAndEval spawns $EDITOR (falling back to "vi") on a scratch file and
+// evaluates whatever was saved, useful for multi-line class definitions
+// that are awkward to type directly at the prompt.
+func (sess *replSession) editAndEval() {
+	tmp, err := ioutil.TempFile("", "magpie-edit-*.mp")
+	if err != nil {
+		fmt.Fprintln(sess.out, err)
+		return
+	}
+	defer os.Remove(tmp.Name())
+	tmp.Close()
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+
+	cmd := exec.Command(editor, tmp.Name())
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintln(sess.out, err)
+		return
+	}
+
+	src, err := ioutil.ReadFile(tmp.Name())
+	if err != nil {
+		fmt.Fprintln(sess.out, err)
+		return
+	}
+
+	if !sess.evalSource(string(src)) {
+		fmt.Fprintln(sess.out, "syntax error in edited source")
+		return
+	}
+	sess.inputs = append(sess.inputs, string(src))
+}
+
+// printType evaluates expr and prints the runtime type of the resulting
+// Object.
+func (sess *replSession) printType(expr string) {
+	lex := lexer.New("", expr)
+	p := parser.New(lex, sess.wd)
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) != 0 {
+		printParserErrors(sess.out, errs)
+		return
+	}
+
+	val := eval.Eval(program, sess.scope)
+	if val == nil {
+		fmt.Fprintln(sess.out, "nil")
+		return
+	}
+	fmt.Fprintln(sess.out, val.Type())
+}
+
+// save writes every input that evaluated successfully this session to
+// file, each followed by a newline, in the order they were entered; a
+// multi-line input keeps its own line breaks.
+func (sess *replSession) save(file string) {
+	content := strings.Join(sess.inputs, "\n") + "\n"
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		fmt.Fprintln(sess.out, err)
+		return
+	}
+	fmt.Fprintf(sess.out, "saved %d input(s) to %s\n", len(sess.inputs), file)
+}
+
+// loadIncludes batch-parses filenames and evaluates each resulting program
+// into scope, in filename order, reporting (but not aborting on) per-file
+// errors the same way a single bad include/import wouldn't stop the rest
+// of a multi-file load.
+func loadIncludes(out io.Writer, filenames []string, scope *eval.Scope) {
+	programs, errs := parser.BatchParse(filenames, batchParseConcurrency)
+	for i, program := range programs {
+		if err := errs[i]; err != nil {
+			fmt.Fprintf(out, "%s: %s\n", filenames[i], err)
+			continue
+		}
+		eval.Eval(program, scope)
+	}
+}
+
 func printParserErrors(out io.Writer, errors []string) {
 	for _, msg := range errors {
 		io.WriteString(out, "\t"+msg+"\n")
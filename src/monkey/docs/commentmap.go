@@ -0,0 +1,131 @@
+package doc
+
+import (
+	"monkey/ast"
+	"sort"
+)
+
+// FileSet stands in for go/token.FileSet in NewCommentMap's signature,
+// scoped down to a single Monkey source file, since Monkey doesn't
+// multiplex several files into one token stream the way the Go compiler
+// does. NewCommentMap does not read it: comments and nodes are measured by
+// the line numbers their own Pos()/End() report.
+type FileSet struct {
+	Lines int // number of lines in the file comments/root were parsed from
+}
+
+// CommentMap associates comment groups with the AST node they most likely
+// document, modeled on go/ast.CommentMap. Where go/ast keys by exact
+// association rules across a token.FileSet, CommentMap uses line proximity:
+// a comment group attaches to a node that starts on the same line the
+// comment ends on (a "trailing" comment), or, failing that, to a node that
+// starts on the line right after the comment ends (a "lead" comment).
+type CommentMap map[ast.Node][]*ast.CommentGroup
+
+// NewCommentMap associates every comment group in comments with a node
+// reachable from root (every top-level statement, plus the members,
+// properties and methods of any class and the entries of any enum) that
+// starts on the line the comment ends on or on the line after it. A
+// comment is never attached to a node that ends before it or that
+// encloses it. Comments with no such node are dropped, matching go/ast's
+// handling of comments with no associated node.
+func NewCommentMap(fset *FileSet, root *ast.Program, comments []*ast.CommentGroup) CommentMap {
+	nodes := collectNodes(root)
+	cmap := make(CommentMap)
+
+	for _, c := range comments {
+		if n := nearestNode(c, nodes); n != nil {
+			cmap[n] = append(cmap[n], c)
+		}
+	}
+	return cmap
+}
+
+// Filter returns the subset of cmap whose nodes are reachable from node
+// (node itself, or for a Program its statements, plus any class's
+// members/properties/methods and any enum's entries).
+func (cmap CommentMap) Filter(node ast.Node) CommentMap {
+	keep := make(map[ast.Node]bool)
+	for _, n := range collectNodes(node) {
+		keep[n] = true
+	}
+
+	filtered := make(CommentMap)
+	for n, groups := range cmap {
+		if keep[n] {
+			filtered[n] = groups
+		}
+	}
+	return filtered
+}
+
+// Update moves every comment group associated with old over to new. It is
+// meant for tools (the formatter, a future refactorer) that replace a node
+// in place and want its comments to follow.
+func (cmap CommentMap) Update(old, new ast.Node) {
+	groups, ok := cmap[old]
+	if !ok {
+		return
+	}
+	delete(cmap, old)
+	cmap[new] = append(cmap[new], groups...)
+}
+
+// collectNodes walks root, gathering every node CommentMap is allowed to
+// attach a comment to, and returns them sorted by start line.
+func collectNodes(root ast.Node) []ast.Node {
+	var nodes []ast.Node
+
+	switch r := root.(type) {
+	case *ast.Program:
+		for _, s := range r.Statements {
+			nodes = append(nodes, collectNodes(s)...)
+		}
+	case *ast.ClassStatement:
+		nodes = append(nodes, r)
+		for _, member := range r.ClassLiteral.Members {
+			nodes = append(nodes, member)
+		}
+		for _, prop := range r.ClassLiteral.Properties {
+			nodes = append(nodes, prop)
+		}
+		for _, fn := range r.ClassLiteral.Methods {
+			nodes = append(nodes, fn)
+		}
+	case *ast.EnumStatement:
+		nodes = append(nodes, r)
+		for _, entry := range r.Entries {
+			nodes = append(nodes, entry)
+		}
+	default:
+		nodes = append(nodes, root)
+	}
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Pos().Line < nodes[j].Pos().Line })
+	return nodes
+}
+
+// nearestNode finds the node whose start line is closest to (and not
+// before) c's end line, within one line — a lead comment sitting directly
+// above its declaration.
+func nearestNode(c *ast.CommentGroup, nodes []ast.Node) ast.Node {
+	end := c.End().Line
+
+	var best ast.Node
+	bestDist := -1
+	for _, n := range nodes {
+		start := n.Pos().Line
+		if start < end {
+			continue
+		}
+		dist := start - end
+		if dist > 1 {
+			continue
+		}
+		if bestDist == -1 || dist < bestDist {
+			best = n
+			bestDist = dist
+		}
+	}
+	return best
+}
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	_ "fmt"
 	"monkey/ast"
+	"monkey/printer"
 	"path/filepath"
 	"regexp"
 	"sort"
@@ -35,7 +36,26 @@ type Value struct {
 	Text string //declaration text
 }
 
+// New builds the documentation for a single file, threading program's own
+// Comments (the full list the parser collected while building it, the same
+// way go/ast.File carries its Comments alongside its Decls) through to
+// NewWithComments so free-standing comments are recovered without every
+// caller having to track a separate comment list of their own.
 func New(name string, program *ast.Program) *File {
+	return NewWithComments(name, program, program.Comments)
+}
+
+// NewWithComments builds the documentation for a single file the same way
+// New does, but also recovers free-standing comments via a CommentMap:
+// comments is the full comment list the parser collected for program,
+// including any it didn't glue to a declaration's .Doc field directly, so
+// that leading, lead, and trailing comments on ClassLiteral members and
+// top-level classes, enums, lets and funcs appear in the generated docs.
+// Comments the map attaches to individual enum entries are not carried
+// into the output: an enum's Value only has the enum's own Doc.
+func NewWithComments(name string, program *ast.Program, comments []*ast.CommentGroup) *File {
+	cmap := NewCommentMap(&FileSet{}, program, comments)
+
 	var classes []*ast.ClassStatement
 	var enums   []*ast.EnumStatement
 	var lets    []*ast.LetStatement
@@ -44,18 +64,22 @@ func New(name string, program *ast.Program) *File {
 	for _, statement := range program.Statements {
 		switch s := statement.(type) {
 		case *ast.ClassStatement:
+			attachDoc(s, &s.Doc, cmap)
 			if s.Doc != nil {
 				classes = append(classes, s)
 			}
 		case *ast.EnumStatement:
+			attachDoc(s, &s.Doc, cmap)
 			if s.Doc != nil {
 				enums = append(enums, s)
 			}
 		case *ast.LetStatement:
+			attachDoc(s, &s.Doc, cmap)
 			if s.Doc != nil {
 				lets = append(lets, s)
 			}
 		case *ast.FunctionStatement:
+			attachDoc(s, &s.Doc, cmap)
 			if s.Doc != nil {
 				funcs = append(funcs, s)
 			}
@@ -64,13 +88,25 @@ func New(name string, program *ast.Program) *File {
 
 	return &File{
 		Name:    filepath.Base(name),
-		Classes: sortedClasses(classes),
+		Classes: sortedClasses(classes, cmap),
 		Enums:   sortedEnums(enums),
 		Lets:    sortedLets(lets),
 		Funcs:   sortedFuncs(funcs),
 	}
 }
 
+// attachDoc fills in *doc from cmap when the parser didn't already glue a
+// comment to node directly -- recovering free-standing lead comments that
+// New would otherwise drop.
+func attachDoc(node ast.Node, doc **ast.CommentGroup, cmap CommentMap) {
+	if *doc != nil {
+		return
+	}
+	if groups := cmap[node]; len(groups) > 0 {
+		*doc = groups[0]
+	}
+}
+
 // ----------------------------------------------------------------------------
 // Markdown document generator
 
@@ -111,13 +147,14 @@ func sortBy(less func(i, j int) bool, swap func(i, j int), n int) {
 	sort.Sort(&data{n, swap, less})
 }
 
-func sortedClasses(classes []*ast.ClassStatement) []*Classes {
+func sortedClasses(classes []*ast.ClassStatement, cmap CommentMap) []*Classes {
 	list := make([]*Classes, len(classes))
 	i := 0
 	for _, c := range classes {
 
 		funcs := make([]*ast.FunctionStatement, 0)
 		for _, fn := range c.ClassLiteral.Methods {
+			attachDoc(fn, &fn.Doc, cmap)
 			if fn.Doc != nil {
 				funcs = append(funcs, fn)
 			}
@@ -125,6 +162,7 @@ func sortedClasses(classes []*ast.ClassStatement) []*Classes {
 
 		props := make([]*ast.PropertyDeclStmt, 0)
 		for _, prop := range c.ClassLiteral.Properties {
+			attachDoc(prop, &prop.Doc, cmap)
 			if prop.Doc != nil {
 				props = append(props, prop)
 			}
@@ -132,6 +170,7 @@ func sortedClasses(classes []*ast.ClassStatement) []*Classes {
 
 		lets := make([]*ast.LetStatement, 0)
 		for _, member := range c.ClassLiteral.Members {
+			attachDoc(member, &member.Doc, cmap)
 			if member.Doc != nil {
 				lets = append(lets, member)
 			}
@@ -141,7 +180,7 @@ func sortedClasses(classes []*ast.ClassStatement) []*Classes {
 			Value: &Value{
 				Name: c.Name.Value,
 				Doc:  c.Doc.Text(),
-				Text: c.Docs(),
+				Text: printer.Sprint(c),
 			},
 			Props: sortedProps(props),
 			Lets:  sortedLets(lets),
@@ -170,7 +209,7 @@ func sortedLets(lets []*ast.LetStatement) []*Value {
 		list[i] = &Value{
 			Name: l.Names[0].Value,
 			Doc:  l.Doc.Text(),
-			Text: l.Docs(),
+			Text: printer.Sprint(l),
 		}
 		i++
 	}
@@ -190,7 +229,7 @@ func sortedEnums(enums []*ast.EnumStatement) []*Value {
 		list[i] = &Value{
 			Name: e.Name.Value,
 			Doc:  e.Doc.Text(),
-			Text: e.Docs(),
+			Text: printer.Sprint(e),
 		}
 		i++
 	}
@@ -210,7 +249,7 @@ func sortedFuncs(funcs []*ast.FunctionStatement) []*Value {
 		list[i] = &Value{
 			Name: f.Name.Value,
 			Doc:  f.Doc.Text(),
-			Text: f.Docs(),
+			Text: printer.Sprint(f),
 		}
 		i++
 	}
@@ -230,7 +269,7 @@ func sortedProps(props []*ast.PropertyDeclStmt) []*Value {
 		list[i] = &Value{
 			Name: p.Name.Value,
 			Doc:  p.Doc.Text(),
-			Text: p.Docs(),
+			Text: printer.Sprint(p),
 		}
 
 		if strings.HasPrefix(p.Name.Value, "this") {
@@ -0,0 +1,269 @@
+package doc
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Renderer turns a *File into a documentation artifact. Implementations
+// decide both the output format (Render) and the file extension Walk
+// should use when writing one file per package member (Ext).
+type Renderer interface {
+	Render(f *File) (string, error)
+	Ext() string
+}
+
+// Generate renders f with r and writes the result to out.
+func Generate(f *File, r Renderer, out io.Writer) error {
+	text, err := r.Render(f)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(out, text)
+	return err
+}
+
+// IndexEntry locates one documented symbol within the multi-file output
+// Walk produces: File is the output filename SetIndex-aware renderers
+// should link to, Anchor the in-page id to jump to within it.
+type IndexEntry struct {
+	Name   string
+	Kind   string // "class", "enum", "let", or "func"
+	File   string
+	Anchor string
+}
+
+// IndexAware is implemented by renderers that want the full cross-file
+// symbol index Walk computes, so a page for one file can link directly to
+// a class/enum/func documented in another instead of only linking within
+// its own page.
+type IndexAware interface {
+	SetIndex(index []IndexEntry)
+}
+
+// Walk renders every file in pkg with r into outDir, one output file per
+// input file, plus an index page ("index"+r.Ext()) linking all of them so
+// the result can be hosted as a static site or wiki. If r implements
+// IndexAware, Walk computes the full cross-file symbol index first and
+// hands it to r before rendering each file, and additionally writes a
+// "search-index.json" mapping every symbol to the file/anchor it lives at,
+// for an editor plugin (or r's own client-side search box) to query.
+func Walk(pkg []*File, r Renderer, outDir string) error {
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return err
+	}
+
+	names := make([]string, len(pkg))
+	for i, f := range pkg {
+		names[i] = strings.TrimSuffix(f.Name, filepath.Ext(f.Name)) + r.Ext()
+	}
+
+	if ia, ok := r.(IndexAware); ok {
+		entries := buildIndex(pkg, names)
+		ia.SetIndex(entries)
+
+		b, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(outDir, "search-index.json"), b, 0644); err != nil {
+			return err
+		}
+	}
+
+	for i, f := range pkg {
+		text, err := r.Render(f)
+		if err != nil {
+			return fmt.Errorf("doc: rendering %s: %w", f.Name, err)
+		}
+		if err := os.WriteFile(filepath.Join(outDir, names[i]), []byte(text), 0644); err != nil {
+			return err
+		}
+	}
+
+	index, err := renderIndex(names, r.Ext())
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outDir, "index"+r.Ext()), []byte(index), 0644)
+}
+
+// buildIndex collects one IndexEntry per documented symbol across pkg,
+// matching each File to the output filename Walk assigned it in names.
+func buildIndex(pkg []*File, names []string) []IndexEntry {
+	var entries []IndexEntry
+	for i, f := range pkg {
+		for _, c := range f.Classes {
+			entries = append(entries, IndexEntry{Name: c.Value.Name, Kind: "class", File: names[i], Anchor: "class-" + c.Value.Name})
+		}
+		for _, e := range f.Enums {
+			entries = append(entries, IndexEntry{Name: e.Name, Kind: "enum", File: names[i], Anchor: "enum-" + e.Name})
+		}
+		for _, l := range f.Lets {
+			entries = append(entries, IndexEntry{Name: l.Name, Kind: "let", File: names[i], Anchor: "let-" + l.Name})
+		}
+		for _, fn := range f.Funcs {
+			entries = append(entries, IndexEntry{Name: fn.Name, Kind: "func", File: names[i], Anchor: "func-" + fn.Name})
+		}
+	}
+	return entries
+}
+
+func renderIndex(names []string, ext string) (string, error) {
+	switch ext {
+	case ".json":
+		b, err := json.MarshalIndent(names, "", "  ")
+		return string(b), err
+	case ".html":
+		var buf strings.Builder
+		buf.WriteString("<!DOCTYPE html>\n<html><head><title>Index</title></head><body>\n<ul>\n")
+		for _, name := range names {
+			fmt.Fprintf(&buf, "<li><a href=%q>%s</a></li>\n", name, name)
+		}
+		buf.WriteString("</ul>\n</body></html>\n")
+		return buf.String(), nil
+	default:
+		var buf strings.Builder
+		buf.WriteString("# Index\n\n")
+		for _, name := range names {
+			fmt.Fprintf(&buf, "- [%s](%s)\n", name, name)
+		}
+		return buf.String(), nil
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Markdown renderer
+
+// MarkdownRenderer renders a *File using the existing MdDocGen templates.
+type MarkdownRenderer struct{}
+
+func (MarkdownRenderer) Render(f *File) (string, error) { return MdDocGen(f), nil }
+func (MarkdownRenderer) Ext() string                    { return ".md" }
+
+// ----------------------------------------------------------------------------
+// JSON renderer
+
+// JSONRenderer renders a *File as the machine-readable schema of its
+// Classes/Enums/Lets/Funcs, suitable for IDE tooling to consume directly.
+type JSONRenderer struct {
+	Indent string // indentation used by json.MarshalIndent; "" for compact output
+}
+
+func (r JSONRenderer) Render(f *File) (string, error) {
+	var (
+		b   []byte
+		err error
+	)
+	if r.Indent != "" {
+		b, err = json.MarshalIndent(f, "", r.Indent)
+	} else {
+		b, err = json.Marshal(f)
+	}
+	return string(b), err
+}
+
+func (JSONRenderer) Ext() string { return ".json" }
+
+// ----------------------------------------------------------------------------
+// HTML renderer
+
+// HTMLRenderer renders a *File as a self-contained HTML page. When driven
+// through Walk (HTMLRenderer implements IndexAware), every page also gets
+// a project-wide nav linking to every Class/Enum/Func across every file --
+// not just the ones on that page -- plus a search box that queries
+// search-index.json client-side to jump straight to the matching page.
+// Used directly via Generate instead of Walk, a page still renders fine;
+// it just has no cross-file links or search index to draw on.
+type HTMLRenderer struct {
+	index []IndexEntry
+}
+
+func (r *HTMLRenderer) SetIndex(index []IndexEntry) { r.index = index }
+
+type htmlPageData struct {
+	*File
+	Index     []IndexEntry
+	HasSearch bool
+}
+
+var htmlTmpl = template.Must(template.New("htmlDoc").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Name}}</title>
+</head>
+<body>
+<h1>{{.Name}}</h1>
+{{if .HasSearch}}<input type="search" id="search" placeholder="Search all symbols...">
+<ul id="search-results"></ul>{{end}}
+
+<ul id="index">
+{{range .Index}}<li><a href="{{.File}}#{{.Anchor}}">{{.Kind}} {{.Name}}</a></li>
+{{end}}</ul>
+
+{{range .Classes}}
+<h2 id="class-{{.Value.Name}}">{{.Value.Name}}</h2>
+<p>{{.Value.Doc}}</p>
+<pre>{{.Value.Text}}</pre>
+{{range .Props}}<h3>{{.Name}}</h3><p>{{.Doc}}</p><pre>{{.Text}}</pre>{{end}}
+{{range .Funcs}}<h3>{{.Name}}</h3><p>{{.Doc}}</p><pre>{{.Text}}</pre>{{end}}
+{{end}}
+
+{{range .Enums}}
+<h2 id="enum-{{.Name}}">{{.Name}}</h2>
+<p>{{.Doc}}</p>
+<pre>{{.Text}}</pre>
+{{end}}
+
+{{range .Lets}}
+<h2 id="let-{{.Name}}">{{.Name}}</h2>
+<p>{{.Doc}}</p>
+<pre>{{.Text}}</pre>
+{{end}}
+
+{{range .Funcs}}
+<h2 id="func-{{.Name}}">{{.Name}}</h2>
+<p>{{.Doc}}</p>
+<pre>{{.Text}}</pre>
+{{end}}
+
+{{if .HasSearch}}<script>
+fetch("search-index.json").then(function(r) { return r.json(); }).then(function(index) {
+	document.getElementById("search").addEventListener("input", function(e) {
+		var q = e.target.value.toLowerCase();
+		var results = document.getElementById("search-results");
+		results.innerHTML = "";
+		if (q === "") { return; }
+		index.filter(function(entry) {
+			return entry.Name.toLowerCase().indexOf(q) !== -1;
+		}).forEach(function(entry) {
+			var li = document.createElement("li");
+			var a = document.createElement("a");
+			a.href = entry.File + "#" + entry.Anchor;
+			a.textContent = entry.Kind + " " + entry.Name + " (" + entry.File + ")";
+			li.appendChild(a);
+			results.appendChild(li);
+		});
+	});
+});
+</script>{{end}}
+</body>
+</html>
+`))
+
+func (r *HTMLRenderer) Render(f *File) (string, error) {
+	var buf strings.Builder
+	data := htmlPageData{File: f, Index: r.index, HasSearch: r.index != nil}
+	if err := htmlTmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func (*HTMLRenderer) Ext() string { return ".html" }
@@ -0,0 +1,262 @@
+// Package printer implements printing of AST nodes as canonically formatted
+// Monkey source code, mirroring the design of Go's go/printer package.
+package printer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"monkey/ast"
+	"strings"
+)
+
+// A Mode value is a set of flags (or 0) that controls the behavior of Fprint.
+type Mode uint
+
+const (
+	// RawFormat reproduces the original blank-line spacing between
+	// top-level statements (derived from each statement's Pos()/End()
+	// line numbers) instead of always emitting exactly one blank line
+	// between them. It does not reproduce token-level spacing within a
+	// statement -- Fprint only sees the AST, not the original source
+	// text, so that level of fidelity isn't available here.
+	RawFormat Mode = 1 << iota
+	// UseSpaces causes tabs for indentation to be expanded to Tabwidth spaces.
+	UseSpaces
+)
+
+// A Config controls the output of Fprint.
+type Config struct {
+	Mode     Mode // printing mode
+	Tabwidth int  // width of tab stops, used when UseSpaces is set
+	Indent   int  // initial indentation level (in tabs)
+}
+
+// Fprint "pretty-prints" program to output and returns the first error, if
+// any, encountered while formatting. The default Config value produces
+// canonically formatted output; set Mode to RawFormat to instead reproduce
+// the original source's blank-line spacing as closely as possible.
+func (cfg *Config) Fprint(output io.Writer, program *ast.Program) error {
+	p := &printer{cfg: *cfg, indent: cfg.Indent}
+	p.program(program)
+	_, err := output.Write(p.buf.Bytes())
+	return err
+}
+
+// Fprint calls (&Config{Tabwidth: 8}).Fprint(output, program).
+func Fprint(output io.Writer, program *ast.Program) error {
+	return (&Config{Tabwidth: 8}).Fprint(output, program)
+}
+
+// Sprint formats a single node the same way Fprint formats a top-level
+// statement, so a caller that only has one declaration -- doc.Value.Text,
+// for instance -- can regenerate canonically-formatted text from the AST
+// instead of relying on that node's own ad-hoc Docs() method, keeping
+// declaration text uniform across the whole generated doc output. The doc
+// comment itself is left out: callers reusing Sprint for a Value.Text
+// already carry the comment separately as Value.Doc, and Fprint's own
+// blank-line-free defaults would otherwise glue it onto the declaration.
+func Sprint(n ast.Node) string {
+	p := &printer{skipDocs: true}
+	p.statement(n)
+	return p.buf.String()
+}
+
+// printer accumulates the formatted output for a single Fprint call.
+type printer struct {
+	cfg    Config
+	buf    bytes.Buffer
+	indent int
+
+	// skipDocs suppresses docCommentOf, for Sprint callers that only want
+	// a declaration's own text, not its doc comment rendered inline.
+	skipDocs bool
+}
+
+func (p *printer) program(prog *ast.Program) {
+	prevEnd := 0
+	for i, stmt := range prog.Statements {
+		if i > 0 {
+			if p.cfg.Mode&RawFormat != 0 {
+				p.blankLines(prevEnd, stmt.Pos().Line)
+			} else {
+				p.newline()
+			}
+		}
+		p.statement(stmt)
+		prevEnd = stmt.End().Line
+		p.newline()
+	}
+}
+
+// blankLines reproduces the blank-line gap the original source had
+// between the previous statement (ending on prevEnd) and this one
+// (starting on start), used only in RawFormat mode.
+func (p *printer) blankLines(prevEnd, start int) {
+	gap := start - prevEnd - 1
+	for i := 0; i < gap; i++ {
+		p.newline()
+	}
+}
+
+func (p *printer) tabs() string {
+	if p.cfg.Mode&UseSpaces != 0 {
+		width := p.cfg.Tabwidth
+		if width <= 0 {
+			width = 4
+		}
+		return strings.Repeat(" ", width*p.indent)
+	}
+	return strings.Repeat("\t", p.indent)
+}
+
+func (p *printer) newline() {
+	p.buf.WriteByte('\n')
+}
+
+func (p *printer) writeIndented(s string) {
+	p.buf.WriteString(p.tabs())
+	p.buf.WriteString(s)
+}
+
+// docCommentOf writes the doc comment text attached to a declaration, one
+// "//" line per line of the original comment, indented to the current level.
+func (p *printer) docCommentOf(doc fmt.Stringer) {
+	if p.skipDocs || doc == nil {
+		return
+	}
+	text := strings.TrimRight(doc.String(), "\n")
+	if text == "" {
+		return
+	}
+	for _, line := range strings.Split(text, "\n") {
+		p.writeIndented("// " + line)
+		p.newline()
+	}
+}
+
+// statement formats a single top-level or nested statement/expression node.
+// Every node type that eval.Debugger.CanStop recognizes as a stoppable
+// statement has a formatting rule here.
+func (p *printer) statement(n ast.Node) {
+	switch s := n.(type) {
+	case *ast.LetStatement:
+		p.letStatement(s)
+	case *ast.ConstStatement:
+		p.writeIndented(s.String())
+	case *ast.ReturnStatement:
+		p.writeIndented(s.String())
+	case *ast.DeferStmt:
+		p.writeIndented(s.String())
+	case *ast.ClassStatement:
+		p.classStatement(s)
+	case *ast.EnumStatement:
+		p.enumStatement(s)
+	case *ast.IfExpression:
+		p.writeIndented(s.String())
+	case *ast.UnlessExpression:
+		p.writeIndented(s.String())
+	case *ast.CaseExpr:
+		p.writeIndented(s.String())
+	case *ast.DoLoop:
+		p.writeIndented(s.String())
+	case *ast.WhileLoop:
+		p.writeIndented(s.String())
+	case *ast.ForLoop:
+		p.writeIndented(s.String())
+	case *ast.ForEverLoop:
+		p.writeIndented(s.String())
+	case *ast.ForEachArrayLoop:
+		p.writeIndented(s.String())
+	case *ast.ForEachDotRange:
+		p.writeIndented(s.String())
+	case *ast.ForEachMapLoop:
+		p.writeIndented(s.String())
+	case *ast.BreakExpression:
+		p.writeIndented("break")
+	case *ast.ContinueExpression:
+		p.writeIndented("continue")
+	case *ast.AssignExpression:
+		p.writeIndented(s.String())
+	case *ast.CallExpression:
+		p.writeIndented(s.String())
+	case *ast.TryStmt:
+		p.writeIndented(s.String())
+	case *ast.SpawnStmt:
+		p.writeIndented(s.String())
+	case *ast.UsingStmt:
+		p.writeIndented(s.String())
+	case *ast.QueryExpr:
+		p.writeIndented(s.String())
+	default:
+		// Fall back to the node's own String() so printer stays usable for
+		// node kinds that don't yet have a dedicated formatting rule.
+		p.writeIndented(n.String())
+	}
+}
+
+func (p *printer) letStatement(s *ast.LetStatement) {
+	if s.Doc != nil {
+		p.docCommentOf(s.Doc)
+	}
+	p.writeIndented(s.String())
+}
+
+func (p *printer) classStatement(s *ast.ClassStatement) {
+	if s.Doc != nil {
+		p.docCommentOf(s.Doc)
+	}
+	p.writeIndented(classHeader(s) + " {")
+	p.newline()
+	p.indent++
+	for _, member := range s.ClassLiteral.Members {
+		if member.Doc != nil {
+			p.docCommentOf(member.Doc)
+		}
+		p.writeIndented(member.String())
+		p.newline()
+	}
+	for _, prop := range s.ClassLiteral.Properties {
+		if prop.Doc != nil {
+			p.docCommentOf(prop.Doc)
+		}
+		p.writeIndented(prop.String())
+		p.newline()
+	}
+	for _, fn := range s.ClassLiteral.Methods {
+		if fn.Doc != nil {
+			p.docCommentOf(fn.Doc)
+		}
+		p.writeIndented(fn.String())
+		p.newline()
+	}
+	p.indent--
+	p.writeIndented("}")
+}
+
+// enumStatement prints an enum declaration's doc comment followed by its
+// own String(), which already renders the full "enum NAME { ... }" form --
+// unlike ClassStatement, EnumStatement has no per-entry doc comments to
+// weave in (see doc.sortedEnums, which never descends into entries), so
+// there's nothing for the printer to rebuild by hand here.
+func (p *printer) enumStatement(s *ast.EnumStatement) {
+	if s.Doc != nil {
+		p.docCommentOf(s.Doc)
+	}
+	p.writeIndented(s.String())
+}
+
+// classHeader returns everything up to (but not including) the opening
+// brace of s.String() -- "class Name", plus whatever parent/base clause
+// and constructor argument list the parser attached to it. classStatement
+// rebuilds the body by hand (to place per-member doc comments), but the
+// header itself has no equivalent per-member reason to be rebuilt field by
+// field, so reusing this slice of String() is how it keeps inheritance and
+// constructor args instead of silently dropping them.
+func classHeader(s *ast.ClassStatement) string {
+	full := s.String()
+	if idx := strings.IndexByte(full, '{'); idx >= 0 {
+		return strings.TrimRight(full[:idx], " \t\n")
+	}
+	return fmt.Sprintf("class %s", s.Name.Value)
+}